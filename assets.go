@@ -3,14 +3,23 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
 	"embed"
+	"encoding/base64"
+	"fmt"
 	"io"
 	"io/fs"
+	"mime"
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
 //go:embed webgui
@@ -19,50 +28,171 @@ var content embed.FS
 var HTTP http.FileSystem
 
 func init() {
-	// Priority: STATIC_DIR > STATIC_ZIP > STATIC_EMBED > DEV_DIR
+	// Base priority: STATIC_ZIP > STATIC_ZIP_URL > zip blob registered via
+	// RegisterZipData > STATIC_EMBED > DEV_DIR. STATIC_DIR and
+	// STATIC_OVERLAY_DIR no longer replace the base outright; they stack on
+	// top of it as overlay layers, so a deployer can patch in a single file
+	// (e.g. a themed index.html or logo.svg) without shipping a full tree.
+	HTTP = baseFS()
+
+	var layers []http.FileSystem
 	if dir := os.Getenv("STATIC_DIR"); dir != "" {
-		HTTP = http.Dir(dir)
+		layers = append(layers, watchedDir(dir))
+	}
+	if overlay := os.Getenv("STATIC_OVERLAY_DIR"); overlay != "" {
+		for _, dir := range strings.Split(overlay, ",") {
+			dir = strings.TrimSpace(dir)
+			if dir != "" {
+				layers = append(layers, http.Dir(dir))
+			}
+		}
+	}
+	if len(layers) == 0 {
 		return
 	}
+	layers = append(layers, HTTP)
+	HTTP = NewOverlayFS(layers)
+}
 
+// baseFS resolves the non-overlay asset source: a remote or local zip, a
+// zip blob registered at link time via RegisterZipData, the assets embedded
+// at build time, or (if none of those are available) the development
+// directory.
+func baseFS() http.FileSystem {
 	if zipPath := os.Getenv("STATIC_ZIP"); zipPath != "" {
-		f, err := os.Open(zipPath)
-		if err != nil {
-			panic(err)
-		}
-		fi, err := f.Stat()
-		if err != nil {
-			f.Close()
-			panic(err)
+		if isHTTPURL(zipPath) {
+			return newZipFSFromURL(zipPath)
 		}
-		zr, err := zip.NewReader(f, fi.Size())
-		if err != nil {
-			f.Close()
-			panic(err)
-		}
-		zs := newZipFS(zr)
-		HTTP = zs
-		return
+		return newZipFSFromFile(zipPath)
+	}
+
+	if zipURL := os.Getenv("STATIC_ZIP_URL"); zipURL != "" {
+		return newZipFSFromURL(zipURL)
+	}
+
+	if registeredZipData != "" {
+		return newZipFSFromRegistered(registeredZipData)
 	}
 
 	// try embedded assets
-	webgui, err := fs.Sub(content, "webgui")
-	if err == nil {
-		HTTP = http.FS(webgui)
-		return
+	if webgui, err := fs.Sub(content, "webgui"); err == nil {
+		return newEmbedFS(webgui)
 	}
 
-	// fallback to development directory
-	HTTP = http.Dir("./webgui")
+	// fallback to development directory, with hot reload since this path is
+	// only ever hit while actively developing the webgui tree
+	return watchedDir("./webgui")
+}
+
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// registeredZipData holds a zip archive registered via RegisterZipData,
+// e.g. by a generated Go file produced by cmd/zipasset. It lets a
+// downstream packager swap the bundled UI by linking one extra file into
+// package main, without needing the assets on disk at `go build` time the
+// way go:embed does.
+var registeredZipData string
+
+// RegisterZipData registers a zip archive (base64-encoded, or raw bytes as
+// a string) to be used as the static asset source. Call it from an init()
+// function so it runs before this package's own init() reads it; Go runs
+// init funcs in the order their files are presented to the compiler, which
+// for a single package is alphabetical, so name the generated file so it
+// sorts before "assets.go" (e.g. "000_generated_assets.go") if ordering
+// matters for your build.
+func RegisterZipData(data string) {
+	registeredZipData = data
+}
+
+// newZipFSFromRegistered builds a zipFS from a blob registered via
+// RegisterZipData. The blob may be base64-encoded (the common case, since
+// cmd/zipasset emits a Go string literal) or raw zip bytes.
+func newZipFSFromRegistered(data string) *zipFS {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		raw = []byte(data)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		panic(err)
+	}
+	return newZipFS(zr, "registered")
+}
+
+// newZipFSFromFile opens a local zip archive and wraps it in a zipFS.
+func newZipFSFromFile(zipPath string) *zipFS {
+	f, err := os.Open(zipPath)
+	if err != nil {
+		panic(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		panic(err)
+	}
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		f.Close()
+		panic(err)
+	}
+	return newZipFS(zr, zipPath)
+}
+
+// newZipFSFromURL opens a remote zip archive over HTTP range reads, so that
+// only the central directory (near EOF) and the entries actually requested
+// are ever fetched. STATIC_ZIP_AUTH, if set, is sent verbatim as the
+// Authorization header on every request (e.g. "Bearer <token>" or
+// "Basic <base64>") for private buckets.
+func newZipFSFromURL(url string) *zipFS {
+	ra, size, err := newHTTPRangeReaderAt(url, os.Getenv("STATIC_ZIP_AUTH"))
+	if err != nil {
+		panic(err)
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		panic(err)
+	}
+	return newZipFS(zr, url)
+}
+
+// zipEagerReadThreshold is the uncompressed size below which zipFS.Open
+// just decompresses a file in full rather than paying the bookkeeping cost
+// of the lazy streaming path.
+const zipEagerReadThreshold = 64 * 1024
+
+// zipEntryCache holds fully-decoded zip entries, keyed by
+// "<zipPath>\x00<entryName>", bounded by STATIC_ZIP_CACHE_BYTES (default
+// 32 MiB) so hot assets stay resident without a multi-MB zip pinning every
+// served file in memory for good.
+var zipEntryCache = newByteBudgetLRU(zipCacheBudgetFromEnv())
+
+const defaultZipCacheBytes = 32 * 1024 * 1024
+
+func zipCacheBudgetFromEnv() int64 {
+	if v := os.Getenv("STATIC_ZIP_CACHE_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultZipCacheBytes
+}
+
+// newByteBudgetLRU builds a byteLRU bounded by total bytes rather than item
+// count, since zip entries vary wildly in size.
+func newByteBudgetLRU(budget int64) *byteLRU {
+	return &byteLRU{capacity: budget, weight: byteWeight, entries: make(map[string][]byte)}
 }
 
 type zipFS struct {
-    files map[string]*zip.File
-    dirs  map[string][]string
+    files   map[string]*zip.File
+    dirs    map[string][]string
+    zipPath string // identifies this archive for the global entry cache
 }
 
-func newZipFS(r *zip.Reader) *zipFS {
-    z := &zipFS{files: make(map[string]*zip.File), dirs: make(map[string][]string)}
+func newZipFS(r *zip.Reader, zipPath string) *zipFS {
+    z := &zipFS{files: make(map[string]*zip.File), dirs: make(map[string][]string), zipPath: zipPath}
     for _, f := range r.File {
         name := path.Clean(f.Name)
         name = strings.TrimPrefix(name, "./")
@@ -117,18 +247,29 @@ func (z *zipFS) Open(name string) (http.File, error) {
 
     // file
     if f, ok := z.files[name]; ok {
-        rc, err := f.Open()
-        if err != nil {
-            return nil, err
+        fi := f.FileHeader.FileInfo()
+
+        // tiny files are simpler to just read eagerly; no point paying the
+        // bookkeeping cost of the lazy path for a few KiB.
+        if fi.Size() < zipEagerReadThreshold {
+            rc, err := f.Open()
+            if err != nil {
+                return nil, err
+            }
+            buf := new(bytes.Buffer)
+            _, err = io.Copy(buf, rc)
+            rc.Close()
+            if err != nil {
+                return nil, err
+            }
+            return &zipHTTPFile{isDir: false, name: path.Base(name), data: buf.Bytes(), fi: fi}, nil
         }
-        // read full content into memory so we can provide Seek
-        buf := new(bytes.Buffer)
-        _, err = io.Copy(buf, rc)
-        rc.Close()
-        if err != nil {
-            return nil, err
+
+        cacheKey := z.zipPath + "\x00" + name
+        if cached, ok := zipEntryCache.get(cacheKey); ok {
+            return &zipHTTPFile{isDir: false, name: path.Base(name), data: cached, fi: fi}, nil
         }
-        return &zipHTTPFile{isDir: false, name: path.Base(name), data: buf.Bytes(), fi: f.FileHeader.FileInfo()}, nil
+        return &zipHTTPFile{isDir: false, name: path.Base(name), zf: f, cacheKey: cacheKey, fi: fi}, nil
     }
 
     // maybe the name refers to a directory without trailing slash
@@ -167,28 +308,122 @@ type zipHTTPFile struct {
     rdr    *bytes.Reader
     fi     os.FileInfo
     entries []os.FileInfo
+
+    // lazy streaming path, used when data is nil: bytes are decompressed
+    // forward from zf on demand and accumulated into buf, which doubles as
+    // this file's own partial cache for backward seeks within the request.
+    zf           *zip.File
+    cacheKey     string
+    rc           io.ReadCloser
+    buf          []byte
+    pos          int64
+    fullyDecoded bool
 }
 
-func (f *zipHTTPFile) Close() error { return nil }
+func (f *zipHTTPFile) Close() error {
+    if f.rc != nil {
+        f.rc.Close()
+        f.rc = nil
+    }
+    return nil
+}
 
 func (f *zipHTTPFile) Read(p []byte) (int, error) {
     if f.isDir {
         return 0, io.EOF
     }
-    if f.rdr == nil {
-        f.rdr = bytes.NewReader(f.data)
+    if f.data != nil {
+        if f.rdr == nil {
+            f.rdr = bytes.NewReader(f.data)
+        }
+        return f.rdr.Read(p)
     }
-    return f.rdr.Read(p)
+
+    if err := f.ensureDecoded(f.pos + int64(len(p))); err != nil {
+        return 0, err
+    }
+    if f.pos >= int64(len(f.buf)) {
+        return 0, io.EOF
+    }
+    n := copy(p, f.buf[f.pos:])
+    f.pos += int64(n)
+    return n, nil
 }
 
 func (f *zipHTTPFile) Seek(offset int64, whence int) (int64, error) {
     if f.isDir {
         return 0, io.EOF
     }
-    if f.rdr == nil {
-        f.rdr = bytes.NewReader(f.data)
+    if f.data != nil {
+        if f.rdr == nil {
+            f.rdr = bytes.NewReader(f.data)
+        }
+        return f.rdr.Seek(offset, whence)
+    }
+
+    var target int64
+    switch whence {
+    case io.SeekStart:
+        target = offset
+    case io.SeekCurrent:
+        target = f.pos + offset
+    case io.SeekEnd:
+        target = f.fi.Size() + offset
+    default:
+        return 0, os.ErrInvalid
+    }
+    if target < 0 {
+        return 0, os.ErrInvalid
     }
-    return f.rdr.Seek(offset, whence)
+    if target > f.fi.Size() {
+        target = f.fi.Size()
+    }
+    // Seek only moves the cursor; it never decompresses. The zip header
+    // already gives us the uncompressed size, so Seek(0, io.SeekEnd) -
+    // which http.ServeContent always issues first to learn Content-Length -
+    // is free. Decompression happens lazily in Read, up to whatever offset
+    // is actually requested: a cold request for a Range near the end of a
+    // large file decompresses the entry from its start up to the end of
+    // that range (deflate cannot skip ahead), but nothing past it.
+    f.pos = target
+    return f.pos, nil
+}
+
+// ensureDecoded decompresses forward until at least `target` bytes are
+// available in buf, or the entry is exhausted. Once the entry is fully
+// decoded it's stashed in the process-global LRU so the next Open for this
+// (zipPath, entryName) skips decompression entirely.
+func (f *zipHTTPFile) ensureDecoded(target int64) error {
+    if f.fullyDecoded || int64(len(f.buf)) >= target {
+        return nil
+    }
+    if f.rc == nil {
+        rc, err := f.zf.Open()
+        if err != nil {
+            return err
+        }
+        f.rc = rc
+    }
+    chunk := make([]byte, 32*1024)
+    for int64(len(f.buf)) < target {
+        n, err := f.rc.Read(chunk)
+        if n > 0 {
+            f.buf = append(f.buf, chunk[:n]...)
+        }
+        if err != nil {
+            f.rc.Close()
+            f.rc = nil
+            if err == io.EOF {
+                f.fullyDecoded = true
+                if f.cacheKey != "" {
+                    zipEntryCache.put(f.cacheKey, f.buf)
+                }
+                return nil
+            }
+            return err
+        }
+    }
+    return nil
 }
 
 func (f *zipHTTPFile) Readdir(count int) ([]os.FileInfo, error) {
@@ -229,3 +464,803 @@ func (z *zipFileInfo) Mode() os.FileMode  { if z.isDir { return os.ModeDir | 055
 func (z *zipFileInfo) ModTime() time.Time { return z.modTime }
 func (z *zipFileInfo) IsDir() bool        { return z.isDir }
 func (z *zipFileInfo) Sys() interface{}   { return nil }
+
+// httpRangeReaderAt is an io.ReaderAt backed by ranged HTTP GETs. It lets
+// zip.NewReader read the central directory and individual entries of a
+// remote zip without downloading the whole archive up front.
+//
+// If the server doesn't honor range requests (no "Accept-Ranges: bytes", or
+// it answers a ranged GET with 200 instead of 206) it falls back to
+// downloading the file once in full and serving every ReadAt from that
+// cached copy.
+type httpRangeReaderAt struct {
+	url        string
+	authHeader string
+	client     *http.Client
+	size       int64
+
+	bytesLRU *byteLRU
+
+	mu     sync.Mutex // guards ranged and full below
+	ranged bool
+	full   []byte // populated once full fallback kicks in
+}
+
+const (
+	httpRangeMaxRetries = 3
+	httpRangeRetryDelay = 200 * time.Millisecond
+)
+
+// newHTTPRangeReaderAt issues a HEAD request to learn the remote object's
+// size and whether it supports range reads, returning a ReaderAt usable
+// directly with zip.NewReader.
+func newHTTPRangeReaderAt(url, authHeader string) (*httpRangeReaderAt, int64, error) {
+	ra := &httpRangeReaderAt{
+		url:        url,
+		authHeader: authHeader,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		bytesLRU:   newByteLRU(64),
+	}
+
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	ra.setAuth(req)
+
+	resp, err := ra.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("assets: HEAD %s: unexpected status %s", url, resp.Status)
+	}
+
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("assets: HEAD %s: missing Content-Length: %w", url, err)
+	}
+	ra.size = size
+	ra.ranged = resp.Header.Get("Accept-Ranges") == "bytes"
+	return ra, size, nil
+}
+
+func (ra *httpRangeReaderAt) setAuth(req *http.Request) {
+	if ra.authHeader != "" {
+		req.Header.Set("Authorization", ra.authHeader)
+	}
+}
+
+// ReadAt implements io.ReaderAt. It serves out of the byte LRU when possible,
+// otherwise issues a ranged GET (or reuses a previously downloaded full
+// copy for servers that don't support ranges).
+func (ra *httpRangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= ra.size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p))
+	if end > ra.size {
+		end = ra.size
+	}
+
+	ra.mu.Lock()
+	full := ra.full
+	ra.mu.Unlock()
+	if full != nil {
+		n := copy(p, full[off:end])
+		if int64(n) < int64(len(p)) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+
+	key := fmt.Sprintf("%d-%d", off, end)
+	if cached, ok := ra.bytesLRU.get(key); ok {
+		n := copy(p, cached)
+		if int64(n) < int64(len(p)) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+
+	data, err := ra.fetchRange(off, end-1)
+	if err != nil {
+		return 0, err
+	}
+	ra.bytesLRU.put(key, data)
+	n := copy(p, data)
+	if int64(n) < int64(len(p)) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetchRange performs a single ranged GET for [start, end] (inclusive),
+// retrying transient 5xx responses with a short backoff. If the server
+// doesn't support ranges it downloads the whole object once and caches it.
+func (ra *httpRangeReaderAt) fetchRange(start, end int64) ([]byte, error) {
+	ra.mu.Lock()
+	ranged := ra.ranged
+	ra.mu.Unlock()
+	if !ranged {
+		return ra.downloadFull(start, end)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < httpRangeMaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(httpRangeRetryDelay * time.Duration(attempt))
+		}
+
+		req, err := http.NewRequest(http.MethodGet, ra.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		ra.setAuth(req)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+		resp, err := ra.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			data, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return data, err
+		case http.StatusRequestedRangeNotSatisfiable:
+			resp.Body.Close()
+			return nil, io.EOF
+		case http.StatusOK:
+			// server ignored the Range header entirely and sent the whole
+			// object; keep it and serve everything from memory from now on.
+			data, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			ra.mu.Lock()
+			ra.ranged = false
+			ra.full = data
+			ra.mu.Unlock()
+			return ra.downloadFull(start, end)
+		default:
+			resp.Body.Close()
+			if resp.StatusCode >= 500 && resp.StatusCode < 600 {
+				lastErr = fmt.Errorf("assets: GET %s: status %s", ra.url, resp.Status)
+				continue
+			}
+			return nil, fmt.Errorf("assets: GET %s: unexpected status %s", ra.url, resp.Status)
+		}
+	}
+	return nil, lastErr
+}
+
+// downloadFull fetches the entire object once and caches it so that every
+// later ReadAt (ranged or not) is served from memory.
+func (ra *httpRangeReaderAt) downloadFull(start, end int64) ([]byte, error) {
+	ra.mu.Lock()
+	full := ra.full
+	ra.mu.Unlock()
+
+	if full == nil {
+		req, err := http.NewRequest(http.MethodGet, ra.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		ra.setAuth(req)
+		resp, err := ra.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("assets: GET %s: unexpected status %s", ra.url, resp.Status)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		full = data
+		ra.mu.Lock()
+		ra.full = full
+		ra.mu.Unlock()
+	}
+	return full[start : end+1], nil
+}
+
+// byteLRU is a least-recently-used cache of byte slices, bounded by a
+// capacity measured in whatever unit weight returns for each entry (one
+// per item for newByteLRU, total bytes for newByteBudgetLRU). It backs
+// both the remote-zip range cache (chunk0-1) and the decoded zip entry
+// cache (chunk0-4), which want count-based and byte-based bounds
+// respectively. zip.Reader issues ReadAt calls concurrently once more than
+// one request is in flight, so every access is guarded by mu.
+type byteLRU struct {
+	mu       sync.Mutex
+	capacity int64
+	used     int64
+	weight   func(data []byte) int64
+	order    []string
+	entries  map[string][]byte
+}
+
+// itemWeight makes capacity count entries rather than bytes.
+func itemWeight(data []byte) int64 { return 1 }
+
+// byteWeight makes capacity bound total bytes rather than entry count.
+func byteWeight(data []byte) int64 { return int64(len(data)) }
+
+func newByteLRU(capacity int) *byteLRU {
+	return &byteLRU{capacity: int64(capacity), weight: itemWeight, entries: make(map[string][]byte)}
+}
+
+func (c *byteLRU) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.touch(key)
+	return data, true
+}
+
+func (c *byteLRU) put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := c.weight(data)
+	if w > c.capacity {
+		return
+	}
+	if existing, exists := c.entries[key]; exists {
+		c.used -= c.weight(existing)
+		delete(c.entries, key)
+		for i, k := range c.order {
+			if k == key {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+	}
+	for c.used+w > c.capacity && len(c.order) > 0 {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		c.used -= c.weight(c.entries[oldest])
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = data
+	c.used += w
+	c.touch(key)
+}
+
+func (c *byteLRU) touch(key string) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, key)
+}
+
+// OverlayFS composes an ordered slice of http.FileSystem layers. Open tries
+// each layer in turn and returns the first hit; for directories it instead
+// synthesizes a merged listing (union of entries, first layer wins on name
+// collision) so a partial overlay directory doesn't hide siblings that only
+// exist in a lower layer.
+type OverlayFS struct {
+	layers []http.FileSystem
+}
+
+// NewOverlayFS builds an OverlayFS from layers ordered highest-precedence
+// first.
+func NewOverlayFS(layers []http.FileSystem) *OverlayFS {
+	return &OverlayFS{layers: layers}
+}
+
+func (o *OverlayFS) Open(name string) (http.File, error) {
+	type hit struct {
+		f  http.File
+		fi os.FileInfo
+	}
+	var hits []hit
+	for _, layer := range o.layers {
+		f, err := layer.Open(name)
+		if err != nil {
+			continue
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			continue
+		}
+		hits = append(hits, hit{f, fi})
+	}
+	if len(hits) == 0 {
+		return nil, os.ErrNotExist
+	}
+
+	if !hits[0].fi.IsDir() {
+		for _, h := range hits[1:] {
+			h.f.Close()
+		}
+		return hits[0].f, nil
+	}
+
+	seen := make(map[string]os.FileInfo)
+	var order []string
+	for _, h := range hits {
+		if !h.fi.IsDir() {
+			h.f.Close()
+			continue
+		}
+		entries, err := h.f.Readdir(-1)
+		h.f.Close()
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if _, ok := seen[e.Name()]; ok {
+				continue
+			}
+			seen[e.Name()] = e
+			order = append(order, e.Name())
+		}
+	}
+	merged := make([]os.FileInfo, 0, len(order))
+	for _, n := range order {
+		merged = append(merged, seen[n])
+	}
+	return &overlayDirFile{name: name, fi: hits[0].fi, entries: merged}, nil
+}
+
+// overlayDirFile is the http.File returned for a directory resolved through
+// OverlayFS; it carries a pre-merged entry list instead of delegating
+// Readdir to a single underlying layer.
+type overlayDirFile struct {
+	name    string
+	fi      os.FileInfo
+	entries []os.FileInfo
+}
+
+func (f *overlayDirFile) Close() error                 { return nil }
+func (f *overlayDirFile) Read(p []byte) (int, error)    { return 0, io.EOF }
+func (f *overlayDirFile) Seek(o int64, w int) (int64, error) { return 0, io.EOF }
+func (f *overlayDirFile) Stat() (os.FileInfo, error)    { return f.fi, nil }
+
+func (f *overlayDirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if count <= 0 || count >= len(f.entries) {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	res := f.entries[:count]
+	f.entries = f.entries[count:]
+	return res, nil
+}
+
+// ETag makes OverlayFS itself an ETagFS, forwarding to whichever layer
+// would actually serve name (the same first-hit layer Open would pick),
+// so STATIC_DIR/STATIC_OVERLAY_DIR stacking on top of a zipFS or embedFS
+// base doesn't silently lose ETag/If-None-Match support.
+func (o *OverlayFS) ETag(name string) (string, bool) {
+	for _, layer := range o.layers {
+		f, err := layer.Open(name)
+		if err != nil {
+			continue
+		}
+		fi, statErr := f.Stat()
+		f.Close()
+		if statErr != nil {
+			continue
+		}
+		if fi.IsDir() {
+			return "", false
+		}
+		if e, ok := layer.(ETagFS); ok {
+			return e.ETag(name)
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// ETagFS is implemented by filesystems that can cheaply produce a strong
+// ETag for a given path without reading the whole file on every request.
+type ETagFS interface {
+	ETag(name string) (string, bool)
+}
+
+// ETag derives a strong ETag from the zip entry's CRC32, which the zip
+// format already stores in the central directory.
+func (z *zipFS) ETag(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean(name), "/")
+	f, ok := z.files[name]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(`"%08x"`, f.CRC32), true
+}
+
+func fileETag(fsys http.FileSystem, name string) (string, bool) {
+	if e, ok := fsys.(ETagFS); ok {
+		return e.ETag(name)
+	}
+	return "", false
+}
+
+// embedFS wraps the go:embed assets with strong ETags computed once at
+// startup (the first 8 bytes of a SHA-256 of each file's bytes, in hex),
+// since embed.FS exposes no
+// per-file checksum of its own.
+type embedFS struct {
+	http.FileSystem
+	etags map[string]string
+}
+
+func newEmbedFS(sub fs.FS) *embedFS {
+	etags := make(map[string]string)
+	fs.WalkDir(sub, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(sub, p)
+		if err != nil {
+			return nil
+		}
+		sum := sha256.Sum256(data)
+		etags["/"+p] = fmt.Sprintf(`"%x"`, sum[:8])
+		return nil
+	})
+	return &embedFS{FileSystem: http.FS(sub), etags: etags}
+}
+
+func (e *embedFS) ETag(name string) (string, bool) {
+	name = path.Clean(name)
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	t, ok := e.etags[name]
+	return t, ok
+}
+
+// NewAssetHandler wraps an http.FileSystem with precompressed asset
+// negotiation: if name+".br" or name+".gz" exists and the request's
+// Accept-Encoding allows it, those bytes are served with the matching
+// Content-Encoding instead of the plain file. The Content-Type is always
+// inferred from the un-suffixed name, and a strong ETag (when the
+// underlying FS can provide one) is honored against If-None-Match.
+func NewAssetHandler(fsys http.FileSystem) http.Handler {
+	return &assetHandler{fs: fsys}
+}
+
+type assetHandler struct {
+	fs http.FileSystem
+}
+
+type assetCandidate struct {
+	path     string
+	encoding string
+}
+
+func (h *assetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean(r.URL.Path)
+
+	accept := r.Header.Get("Accept-Encoding")
+	candidates := make([]assetCandidate, 0, 3)
+	if strings.Contains(accept, "br") {
+		candidates = append(candidates, assetCandidate{name + ".br", "br"})
+	}
+	if strings.Contains(accept, "gzip") {
+		candidates = append(candidates, assetCandidate{name + ".gz", "gzip"})
+	}
+	candidates = append(candidates, assetCandidate{name, ""})
+
+	var f http.File
+	var fi os.FileInfo
+	var chosen assetCandidate
+	for _, c := range candidates {
+		cf, err := h.fs.Open(c.path)
+		if err != nil {
+			continue
+		}
+		cfi, err := cf.Stat()
+		if err != nil || cfi.IsDir() {
+			cf.Close()
+			continue
+		}
+		f, fi, chosen = cf, cfi, c
+		break
+	}
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	if chosen.encoding != "" {
+		w.Header().Set("Content-Encoding", chosen.encoding)
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	if etag, ok := fileETag(h.fs, chosen.path); ok {
+		w.Header().Set("ETag", etag)
+	}
+
+	http.ServeContent(w, r, "", fi.ModTime(), f)
+}
+
+// assetsWatcher is the most recently created watchedDirFS, if any. It
+// backs AssetEventsHandler, which an HTTP server can mount (e.g. at
+// /_assets/events) so a dev page can live-reload while someone edits the
+// webgui tree; nothing in this package mounts it.
+var assetsWatcher *watchedDirFS
+
+// watchedDir wraps dir in a watchedDirFS and records it as the active
+// watcher for AssetEventsHandler. If the fsnotify watcher can't be started
+// (e.g. inotify limits reached) it degrades to a plain http.Dir.
+func watchedDir(dir string) http.FileSystem {
+	w, err := newWatchedDirFS(dir)
+	if err != nil {
+		return http.Dir(dir)
+	}
+	assetsWatcher = w
+	return w
+}
+
+// AssetEventsHandler serves a Server-Sent-Events stream that emits the
+// path of every file created, written, removed or renamed under the
+// active STATIC_DIR (or development) tree, so a dev page can auto-reload.
+// A client that falls more than 8 events behind misses the events sent
+// while its buffer is full. Returns 404 if no watched directory is in use.
+func AssetEventsHandler() http.Handler {
+	if assetsWatcher == nil {
+		return http.NotFoundHandler()
+	}
+	return assetsWatcher.eventsHandler()
+}
+
+// watchedFileMeta is the cached stat metadata watchedDirFS keeps per file
+// so the serving handler can answer conditional requests without a stat
+// syscall on every hit.
+type watchedFileMeta struct {
+	modTime time.Time
+	size    int64
+	etag    string
+	isDir   bool
+}
+
+// watchedDirFS serves files out of a directory on disk while keeping an
+// in-memory index of size/mtime/ETag up to date via fsnotify, so repeated
+// requests for the same asset during UI development don't each cost a
+// stat(2) call, and so changes are cheaply observable for hot reload.
+type watchedDirFS struct {
+	root    string
+	dir     http.Dir
+	watcher *fsnotify.Watcher
+
+	mu    sync.RWMutex
+	index map[string]watchedFileMeta
+
+	subMu       sync.Mutex
+	subscribers map[chan string]struct{}
+}
+
+func newWatchedDirFS(root string) (*watchedDirFS, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &watchedDirFS{
+		root:        root,
+		dir:         http.Dir(root),
+		watcher:     watcher,
+		index:       make(map[string]watchedFileMeta),
+		subscribers: make(map[chan string]struct{}),
+	}
+	w.rebuildIndex()
+	w.addWatches(root)
+	go w.watchLoop()
+	return w, nil
+}
+
+func (w *watchedDirFS) Open(name string) (http.File, error) {
+	return w.dir.Open(name)
+}
+
+func (w *watchedDirFS) rebuildIndex() {
+	w.indexSubtree(w.root)
+}
+
+// indexSubtree walks root and records metadata for every entry found, used
+// both for the initial index build and to catch up a subtree that appeared
+// in one shot (e.g. a directory created by mv/rsync/checkout already
+// containing files) — a single fsnotify Create event for the new directory
+// says nothing about what's inside it.
+func (w *watchedDirFS) indexSubtree(root string) {
+	filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		w.setMeta(p, info)
+		return nil
+	})
+}
+
+func (w *watchedDirFS) addWatches(root string) {
+	filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			w.watcher.Add(p)
+		}
+		return nil
+	})
+}
+
+func (w *watchedDirFS) relPath(absPath string) string {
+	rel, err := filepath.Rel(w.root, absPath)
+	if err != nil {
+		rel = absPath
+	}
+	return "/" + filepath.ToSlash(rel)
+}
+
+func (w *watchedDirFS) setMeta(absPath string, info os.FileInfo) {
+	meta := watchedFileMeta{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		isDir:   info.IsDir(),
+		etag:    fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}
+	w.mu.Lock()
+	w.index[w.relPath(absPath)] = meta
+	w.mu.Unlock()
+}
+
+func (w *watchedDirFS) watchLoop() {
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(ev)
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (w *watchedDirFS) handleEvent(ev fsnotify.Event) {
+	rel := w.relPath(ev.Name)
+
+	if ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		w.mu.Lock()
+		delete(w.index, rel)
+		w.mu.Unlock()
+		w.broadcast(rel)
+		return
+	}
+
+	info, err := os.Stat(ev.Name)
+	if err != nil {
+		// file vanished between the event firing and us stat-ing it
+		w.mu.Lock()
+		delete(w.index, rel)
+		w.mu.Unlock()
+		w.broadcast(rel)
+		return
+	}
+	w.setMeta(ev.Name, info)
+	if info.IsDir() && ev.Op&fsnotify.Create != 0 {
+		w.addWatches(ev.Name)
+		w.indexSubtree(ev.Name)
+	}
+	w.broadcast(rel)
+}
+
+func (w *watchedDirFS) subscribe(ch chan string) {
+	w.subMu.Lock()
+	w.subscribers[ch] = struct{}{}
+	w.subMu.Unlock()
+}
+
+func (w *watchedDirFS) unsubscribe(ch chan string) {
+	w.subMu.Lock()
+	delete(w.subscribers, ch)
+	w.subMu.Unlock()
+}
+
+func (w *watchedDirFS) broadcast(path string) {
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+	for ch := range w.subscribers {
+		select {
+		case ch <- path:
+		default:
+			// slow subscriber; drop the event rather than block the watcher
+		}
+	}
+}
+
+// ServeHTTP answers a request straight out of the cached index when
+// possible: conditional requests (If-None-Match / If-Modified-Since) are
+// resolved from the in-memory metadata, with no stat(2) call, and only a
+// genuine 200 response touches the filesystem.
+func (w *watchedDirFS) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	name := path.Clean(r.URL.Path)
+
+	w.mu.RLock()
+	meta, ok := w.index[name]
+	w.mu.RUnlock()
+	if !ok || meta.isDir {
+		http.NotFound(rw, r)
+		return
+	}
+
+	rw.Header().Set("ETag", meta.etag)
+	rw.Header().Set("Last-Modified", meta.modTime.UTC().Format(http.TimeFormat))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" && inm == meta.etag {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil && !meta.modTime.Truncate(time.Second).After(t) {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	f, err := w.Open(name)
+	if err != nil {
+		http.NotFound(rw, r)
+		return
+	}
+	defer f.Close()
+
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		rw.Header().Set("Content-Type", ct)
+	}
+	io.Copy(rw, f)
+}
+
+// eventsHandler serves an SSE stream of relative paths that changed under
+// the watched tree.
+func (w *watchedDirFS) eventsHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		flusher, ok := rw.(http.Flusher)
+		if !ok {
+			http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.Header().Set("Connection", "keep-alive")
+
+		ch := make(chan string, 8)
+		w.subscribe(ch)
+		defer w.unsubscribe(ch)
+
+		for {
+			select {
+			case changed := <-ch:
+				fmt.Fprintf(rw, "data: %s\n\n", changed)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
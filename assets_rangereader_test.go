@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// rangeServer builds an httptest.Server around a fixed body, calling
+// respond for each request so individual tests can simulate servers that
+// don't really support ranges, reject out-of-range requests, or flake
+// transiently.
+func rangeServer(t *testing.T, body string, respond func(w http.ResponseWriter, r *http.Request, body string)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPRangeReaderAtBasicRangedRead(t *testing.T) {
+	body := "abcdefghijklmnopqrstuvwxyz"
+	var getCount int32
+	srv := rangeServer(t, body, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		atomic.AddInt32(&getCount, 1)
+		rng := r.Header.Get("Range")
+		if rng == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		start, end := parseRangeHeader(rng)
+		w.Header().Set("Content-Range", "bytes "+rng[len("bytes="):]+"/"+strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, body[start:end+1])
+	})
+
+	ra, size, err := newHTTPRangeReaderAt(srv.URL, "")
+	if err != nil {
+		t.Fatalf("newHTTPRangeReaderAt: %v", err)
+	}
+	if size != int64(len(body)) {
+		t.Fatalf("size = %d, want %d", size, len(body))
+	}
+
+	buf := make([]byte, 5)
+	n, err := ra.ReadAt(buf, 3)
+	if err != nil {
+		t.Fatalf("ReadAt: %v", err)
+	}
+	if got := string(buf[:n]); got != "defgh" {
+		t.Errorf("ReadAt(3) = %q, want %q", got, "defgh")
+	}
+	if atomic.LoadInt32(&getCount) != 1 {
+		t.Fatalf("expected exactly one ranged GET, got %d", getCount)
+	}
+}
+
+func TestHTTPRangeReaderAtFallsBackOn200(t *testing.T) {
+	body := "the quick brown fox jumps over the lazy dog"
+	var getCount int32
+	srv := rangeServer(t, body, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// server claims range support but ignores the Range header
+		atomic.AddInt32(&getCount, 1)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, body)
+	})
+
+	ra, _, err := newHTTPRangeReaderAt(srv.URL, "")
+	if err != nil {
+		t.Fatalf("newHTTPRangeReaderAt: %v", err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := ra.ReadAt(buf, 4); err != nil {
+		t.Fatalf("ReadAt: %v", err)
+	}
+	if got, want := string(buf), body[4:9]; got != want {
+		t.Errorf("ReadAt(4) = %q, want %q", got, want)
+	}
+
+	// a second read at a different offset must not hit the server again:
+	// the 200 fallback should have cached the full body.
+	buf2 := make([]byte, 3)
+	if _, err := ra.ReadAt(buf2, 0); err != nil {
+		t.Fatalf("second ReadAt: %v", err)
+	}
+	if got := string(buf2); got != "the" {
+		t.Errorf("ReadAt(0) = %q, want %q", got, "the")
+	}
+	if atomic.LoadInt32(&getCount) != 1 {
+		t.Fatalf("expected exactly one full GET after falling back, got %d", getCount)
+	}
+}
+
+func TestHTTPRangeReaderAt416(t *testing.T) {
+	body := "short"
+	srv := rangeServer(t, body, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	})
+
+	ra, _, err := newHTTPRangeReaderAt(srv.URL, "")
+	if err != nil {
+		t.Fatalf("newHTTPRangeReaderAt: %v", err)
+	}
+
+	// call fetchRange directly so we can exercise the 416 path regardless
+	// of ReadAt's own off>=size short-circuit.
+	if _, err := ra.fetchRange(0, 4); err != io.EOF {
+		t.Fatalf("fetchRange on 416 = %v, want io.EOF", err)
+	}
+}
+
+func TestHTTPRangeReaderAtRetriesOn5xx(t *testing.T) {
+	body := "retry me"
+	var attempts int32
+	srv := rangeServer(t, body, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		n := atomic.AddInt32(&attempts, 1)
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Range", "bytes 0-3/8")
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, body[:4])
+	})
+
+	ra, _, err := newHTTPRangeReaderAt(srv.URL, "")
+	if err != nil {
+		t.Fatalf("newHTTPRangeReaderAt: %v", err)
+	}
+
+	buf := make([]byte, 4)
+	n, err := ra.ReadAt(buf, 0)
+	if err != nil {
+		t.Fatalf("ReadAt after retries: %v", err)
+	}
+	if got := string(buf[:n]); got != "retr" {
+		t.Errorf("ReadAt(0) = %q, want %q", got, "retr")
+	}
+	if atomic.LoadInt32(&attempts) != 3 {
+		t.Fatalf("expected 3 attempts (2 failures + 1 success), got %d", attempts)
+	}
+}
+
+// parseRangeHeader parses a "bytes=start-end" Range header value, as sent
+// by httpRangeReaderAt.fetchRange. It's only used by this file's fake
+// servers, so malformed input (which would indicate a bug in
+// httpRangeReaderAt itself) just yields zero values rather than failing
+// the test from the server's own goroutine.
+func parseRangeHeader(rangeHeader string) (start, end int) {
+	span := strings.TrimPrefix(rangeHeader, "bytes=")
+	before, after, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0
+	}
+	start, _ = strconv.Atoi(before)
+	end, _ = strconv.Atoi(after)
+	return start, end
+}
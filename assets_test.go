@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// fakeInfo is a minimal os.FileInfo for constructing deterministic
+// in-memory http.FileSystem layers in tests, so merge ordering doesn't
+// depend on real directory-entry order (which the OS doesn't guarantee).
+type fakeInfo struct {
+	name  string
+	isDir bool
+	size  int64
+}
+
+func (fi *fakeInfo) Name() string       { return fi.name }
+func (fi *fakeInfo) Size() int64        { return fi.size }
+func (fi *fakeInfo) Mode() os.FileMode  { return 0444 }
+func (fi *fakeInfo) ModTime() time.Time { return time.Time{} }
+func (fi *fakeInfo) IsDir() bool        { return fi.isDir }
+func (fi *fakeInfo) Sys() interface{}   { return nil }
+
+// fakeFile is a minimal http.File backed by an in-memory byte slice or a
+// fixed, caller-supplied directory listing.
+type fakeFile struct {
+	name    string
+	isDir   bool
+	content []byte
+	entries []os.FileInfo
+	pos     int
+}
+
+func (f *fakeFile) Close() error { return nil }
+
+func (f *fakeFile) Read(p []byte) (int, error) {
+	if f.isDir {
+		return 0, io.EOF
+	}
+	n := copy(p, f.content[f.pos:])
+	f.pos += n
+	if f.pos >= len(f.content) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *fakeFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, os.ErrInvalid
+}
+
+func (f *fakeFile) Readdir(count int) ([]os.FileInfo, error) {
+	return f.entries, nil
+}
+
+func (f *fakeFile) Stat() (os.FileInfo, error) {
+	return &fakeInfo{name: f.name, isDir: f.isDir, size: int64(len(f.content))}, nil
+}
+
+// fakeFS is a tiny http.FileSystem keyed by cleaned path, used to give
+// OverlayFS tests full control over directory-entry order.
+type fakeFS struct {
+	files map[string]*fakeFile
+}
+
+func (fs *fakeFS) Open(name string) (http.File, error) {
+	f, ok := fs.files[path.Clean(name)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	cp := *f // fresh read position per Open, like a real filesystem
+	return &cp, nil
+}
+
+func newOverlayTestLayers() (overlay, base *fakeFS) {
+	overlay = &fakeFS{files: map[string]*fakeFile{
+		"/": {isDir: true, entries: []os.FileInfo{
+			&fakeInfo{name: "shared.txt"},
+			&fakeInfo{name: "only_overlay.txt"},
+		}},
+		"/shared.txt":       {name: "shared.txt", content: []byte("overlay-version")},
+		"/only_overlay.txt": {name: "only_overlay.txt", content: []byte("overlay-only")},
+	}}
+	base = &fakeFS{files: map[string]*fakeFile{
+		"/": {isDir: true, entries: []os.FileInfo{
+			&fakeInfo{name: "only_base.txt"},
+			&fakeInfo{name: "shared.txt"},
+		}},
+		"/shared.txt":    {name: "shared.txt", content: []byte("base-version")},
+		"/only_base.txt": {name: "only_base.txt", content: []byte("base-only")},
+	}}
+	return overlay, base
+}
+
+func readAll(t *testing.T, fsys http.FileSystem, name string) string {
+	t.Helper()
+	f, err := fsys.Open(name)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll(%q): %v", name, err)
+	}
+	return string(data)
+}
+
+func TestOverlayFSShadowsBase(t *testing.T) {
+	overlay, base := newOverlayTestLayers()
+	ofs := NewOverlayFS([]http.FileSystem{overlay, base})
+
+	if got := readAll(t, ofs, "/shared.txt"); got != "overlay-version" {
+		t.Errorf("shared.txt = %q, want the overlay's version", got)
+	}
+}
+
+func TestOverlayFSOverlayOnlyFileVisible(t *testing.T) {
+	overlay, base := newOverlayTestLayers()
+	ofs := NewOverlayFS([]http.FileSystem{overlay, base})
+
+	if got := readAll(t, ofs, "/only_overlay.txt"); got != "overlay-only" {
+		t.Errorf("only_overlay.txt = %q, want %q", got, "overlay-only")
+	}
+}
+
+func TestOverlayFSBaseOnlyFileVisible(t *testing.T) {
+	overlay, base := newOverlayTestLayers()
+	ofs := NewOverlayFS([]http.FileSystem{overlay, base})
+
+	if got := readAll(t, ofs, "/only_base.txt"); got != "base-only" {
+		t.Errorf("only_base.txt = %q, want %q", got, "base-only")
+	}
+}
+
+func TestOverlayFSMergedReaddir(t *testing.T) {
+	overlay, base := newOverlayTestLayers()
+	ofs := NewOverlayFS([]http.FileSystem{overlay, base})
+
+	dir, err := ofs.Open("/")
+	if err != nil {
+		t.Fatalf("Open(\"/\"): %v", err)
+	}
+	defer dir.Close()
+
+	entries, err := dir.Readdir(-1)
+	if err != nil {
+		t.Fatalf("Readdir: %v", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	want := []string{"shared.txt", "only_overlay.txt", "only_base.txt"}
+	if len(names) != len(want) {
+		t.Fatalf("Readdir returned %v, want %v", names, want)
+	}
+	for i, name := range want {
+		if names[i] != name {
+			t.Errorf("entry %d = %q, want %q (names=%v)", i, names[i], name, names)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+)
+
+// buildTestZip returns a zip archive containing a single large entry whose
+// bytes are a deterministic pattern that repeats only every 251 bytes, so
+// reads at arbitrary offsets can be checked for correctness.
+func buildTestZip(t *testing.T, name string, size int) (zipBytes []byte, data []byte) {
+	t.Helper()
+	data = make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251) // 251 is prime, avoids short repeats
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatalf("zw.Create: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("zw.Write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zw.Close: %v", err)
+	}
+	return buf.Bytes(), data
+}
+
+func openLazyZipEntry(t *testing.T, zipPath, name string, zipData []byte) *zipHTTPFile {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	zfs := newZipFS(zr, zipPath)
+	f, err := zfs.Open(name)
+	if err != nil {
+		t.Fatalf("zfs.Open(%q): %v", name, err)
+	}
+	zf, ok := f.(*zipHTTPFile)
+	if !ok {
+		t.Fatalf("Open returned %T, want *zipHTTPFile", f)
+	}
+	if zf.zf == nil {
+		t.Fatalf("entry of size %d took the eager path; want the lazy streaming path", zf.fi.Size())
+	}
+	return zf
+}
+
+func TestZipHTTPFileSeekEndDoesNotDecode(t *testing.T) {
+	const size = 200_000 // well above zipEagerReadThreshold
+	zipData, data := buildTestZip(t, "big.bin", size)
+	zf := openLazyZipEntry(t, "seekend.zip", "big.bin", zipData)
+	defer zf.Close()
+
+	pos, err := zf.Seek(0, io.SeekEnd)
+	if err != nil {
+		t.Fatalf("Seek(0, SeekEnd): %v", err)
+	}
+	if pos != int64(len(data)) {
+		t.Fatalf("Seek(0, SeekEnd) = %d, want %d", pos, len(data))
+	}
+	if len(zf.buf) != 0 {
+		t.Fatalf("Seek(0, SeekEnd) decoded %d bytes; want 0 (size comes from the zip header, not decompression)", len(zf.buf))
+	}
+	if zf.fullyDecoded {
+		t.Fatalf("Seek(0, SeekEnd) marked the entry fully decoded; it shouldn't decode at all")
+	}
+}
+
+func TestZipHTTPFileForwardAndBackwardSeek(t *testing.T) {
+	const size = 200_000
+	zipData, data := buildTestZip(t, "big.bin", size)
+	zf := openLazyZipEntry(t, "seek.zip", "big.bin", zipData)
+	defer zf.Close()
+
+	// a small read at the start only decodes as far as needed
+	buf := make([]byte, 10)
+	if _, err := zf.Read(buf); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !bytes.Equal(buf, data[:10]) {
+		t.Fatalf("initial Read = %v, want %v", buf, data[:10])
+	}
+	if len(zf.buf) >= size {
+		t.Fatalf("a 10-byte read decoded the whole %d-byte entry; streaming isn't lazy", size)
+	}
+
+	// forward seek past what's cached so far, then read: must decode through
+	if _, err := zf.Seek(50_000, io.SeekStart); err != nil {
+		t.Fatalf("Seek(50000): %v", err)
+	}
+	buf = make([]byte, 10)
+	if _, err := zf.Read(buf); err != nil {
+		t.Fatalf("Read after forward seek: %v", err)
+	}
+	if !bytes.Equal(buf, data[50_000:50_010]) {
+		t.Fatalf("Read after forward seek = %v, want %v", buf, data[50_000:50_010])
+	}
+
+	// backward seek reuses the bytes already decoded; no error, correct data
+	if _, err := zf.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek(0): %v", err)
+	}
+	buf = make([]byte, 10)
+	if _, err := zf.Read(buf); err != nil {
+		t.Fatalf("Read after backward seek: %v", err)
+	}
+	if !bytes.Equal(buf, data[:10]) {
+		t.Fatalf("Read after backward seek = %v, want %v", buf, data[:10])
+	}
+}
+
+func TestZipHTTPFileCompletedEntryPopulatesGlobalCache(t *testing.T) {
+	const size = 200_000
+	zipPath := "cache.zip"
+	zipData, data := buildTestZip(t, "big.bin", size)
+	zf := openLazyZipEntry(t, zipPath, "big.bin", zipData)
+
+	got, err := io.ReadAll(zf)
+	zf.Close()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("full read mismatched the original %d bytes", size)
+	}
+	if !zf.fullyDecoded {
+		t.Fatalf("reading to EOF didn't mark the entry fully decoded")
+	}
+
+	cacheKey := zipPath + "\x00" + "big.bin"
+	cached, ok := zipEntryCache.get(cacheKey)
+	if !ok {
+		t.Fatalf("completed entry was never stored in the global zip entry cache")
+	}
+	if !bytes.Equal(cached, data) {
+		t.Fatalf("cached entry doesn't match the original data")
+	}
+
+	// re-opening the same (zipPath, entryName) should now serve straight
+	// from the cache rather than re-decompressing.
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	zfs := newZipFS(zr, zipPath)
+	f, err := zfs.Open("big.bin")
+	if err != nil {
+		t.Fatalf("second Open: %v", err)
+	}
+	defer f.Close()
+	second, ok := f.(*zipHTTPFile)
+	if !ok {
+		t.Fatalf("second Open returned %T, want *zipHTTPFile", f)
+	}
+	if second.zf != nil {
+		t.Fatalf("second Open still carries a *zip.File; expected the cached-bytes fast path")
+	}
+	if !bytes.Equal(second.data, data) {
+		t.Fatalf("second Open's cached data doesn't match the original")
+	}
+}
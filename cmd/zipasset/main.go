@@ -0,0 +1,100 @@
+// Command zipasset walks a directory, zips it up, and emits a small Go
+// source file that registers the result with RegisterZipData (assets.go).
+// It's the companion to the registered-zip asset source in assets.go:
+// downstream packagers who want to ship a custom webgui without rebuilding
+// supervisord from a source tree that has the assets under go:embed can
+// instead link in the file this tool produces.
+//
+// Usage:
+//
+//	zipasset -dir ./webgui -out 000_generated_assets.go
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+var tmpl = template.Must(template.New("zipasset").Parse(`// Code generated by cmd/zipasset. DO NOT EDIT.
+
+package main
+
+func init() {
+	RegisterZipData("{{.Data}}")
+}
+`))
+
+func main() {
+	dir := flag.String("dir", "", "directory to zip up")
+	// Named so it sorts before "assets.go": Go runs init funcs in the order
+	// their files are presented to the compiler, which for a plain `go
+	// build` is alphabetical, and RegisterZipData must run before
+	// assets.go's own init() reads registeredZipData.
+	out := flag.String("out", "000_generated_assets.go", "path of the Go file to write")
+	flag.Parse()
+
+	if *dir == "" {
+		log.Fatal("zipasset: -dir is required")
+	}
+
+	data, err := zipDir(*dir)
+	if err != nil {
+		log.Fatalf("zipasset: %v", err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("zipasset: %v", err)
+	}
+	defer f.Close()
+
+	if err := tmpl.Execute(f, struct{ Data string }{base64.StdEncoding.EncodeToString(data)}); err != nil {
+		log.Fatalf("zipasset: %v", err)
+	}
+}
+
+// zipDir archives every regular file under dir into an in-memory zip,
+// using slash-separated paths relative to dir as entry names.
+func zipDir(dir string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(w, src)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", dir, err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}